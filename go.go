@@ -2,109 +2,279 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
-)
 
-const (
-	inputFile      = "data.csv"       // 输入 CSV 文件
-	goodFile       = "good.csv"       // 正常的 CSV 文件
-	badFile        = "bad.csv"        // 异常的 CSV 文件
-	httpTimeout    = 10 * time.Second // 每个 HTTP 请求的超时时间
-	maxConcurrency = 4000             // 最大并发数
-	readBufferSize = 1024 * 64        // 64KB 缓冲区大小
-	globalTimeout  = 10 * time.Minute // 全局超时设置
+	"github.com/Skylor-Tang/Gopher/pkg/urlcheck"
 )
 
-type SafeCounter struct {
-	processed atomic.Value
+// progressBar 在终端渲染一个带 ETA 和吞吐量统计的进度条
+type progressBar struct {
+	total    int
+	start    time.Time
+	barWidth int
 }
 
-func (sc *SafeCounter) Increment() {
-	for {
-		oldProcessed := sc.processed.Load()
-		newProcessed := oldProcessed.(int) + 1
-
-		if sc.processed.CompareAndSwap(oldProcessed, newProcessed) {
-			break
-		}
-		// 失败重试
+func newProgressBar(total int) *progressBar {
+	return &progressBar{
+		total:    total,
+		start:    time.Now(),
+		barWidth: 40,
 	}
 }
 
-func (sc *SafeCounter) Add(n int) {
-	for {
-		oldProcessed := sc.processed.Load()
-		newProcessed := oldProcessed.(int) + n
+// render 根据当前的统计快照绘制进度条、ETA、耗时和吞吐量
+func (pb *progressBar) render(s StatsSnapshot) {
+	if pb.total <= 0 {
+		return
+	}
 
-		if sc.processed.CompareAndSwap(oldProcessed, newProcessed) {
-			break
-		}
-		// 失败重试
+	processed := int(s.Total)
+	ratio := float64(processed) / float64(pb.total)
+	if ratio > 1 {
+		ratio = 1
+	}
+
+	filled := int(ratio * float64(pb.barWidth))
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", pb.barWidth-filled)
+
+	elapsed := time.Since(pb.start)
+	throughput := float64(processed) / elapsed.Seconds()
+
+	var eta time.Duration
+	if throughput > 0 {
+		remaining := pb.total - processed
+		eta = time.Duration(float64(remaining)/throughput) * time.Second
 	}
+
+	fmt.Printf("\r[%s] %.2f%% (%d/%d) | 成功 %d 失败 %d 超时 %d 重试 %d | %.1f req/s | 已用 %v | 预计剩余 %v",
+		bar, ratio*100, processed, pb.total, s.OK, s.Fail, s.Timeout, s.Retried, throughput, elapsed.Round(time.Second), eta.Round(time.Second))
 }
 
-func (sc *SafeCounter) GetProcessed() int {
-	return sc.processed.Load().(int)
+// finish 在处理结束后换行并打印最终统计信息
+func (pb *progressBar) finish(s StatsSnapshot) {
+	pb.render(s)
+	fmt.Printf("\n完成，共处理 %d 条（成功 %d，失败 %d，超时 %d，重试 %d），读取 %d 字节，耗时 %v\n",
+		s.Total, s.OK, s.Fail, s.Timeout, s.Retried, s.BytesRead, time.Since(pb.start).Round(time.Second))
 }
 
-var (
-	// 安全的进度变量
-	lineCount = &SafeCounter{}
-)
+const defaultLinkColumn = "发布链接" // 默认的链接列名
+
+// config 保存所有可通过命令行配置的运行参数
+type config struct {
+	inputPath      string
+	goodPath       string
+	badPath        string
+	httpTimeout    time.Duration
+	maxConcurrency int
+	readBufferSize int
+	globalTimeout  time.Duration
+	linkColumn     string
+	method         string
+	userAgent      string
+	insecure       bool
+	acceptStatus   string
+	maxRetries     int
+	delimiter      string
+	resume         bool
+
+	accept        func(code int) bool
+	delimiterRune rune
+}
 
-// 创建 HTTP 客户端，启用持久连接
-func createHttpClient() *http.Client {
-	return &http.Client{
-		Timeout: httpTimeout,
-		Transport: &http.Transport{
-			DisableKeepAlives: false, // 启用持久连接
-		},
+// parseFlags 解析命令行参数并构建运行配置
+func parseFlags() (*config, error) {
+	cfg := &config{}
+
+	flag.StringVar(&cfg.inputPath, "input", "data.csv", "输入 CSV 文件路径，'-' 表示从标准输入读取")
+	flag.StringVar(&cfg.goodPath, "good-file", "good.csv", "有效链接输出文件，'-' 表示写到标准输出")
+	flag.StringVar(&cfg.badPath, "bad-file", "bad.csv", "无效链接输出文件，'-' 表示写到标准输出")
+	flag.DurationVar(&cfg.httpTimeout, "http-timeout", 10*time.Second, "单个 HTTP 请求的超时时间")
+	flag.IntVar(&cfg.maxConcurrency, "concurrency", 4000, "最大并发数")
+	flag.IntVar(&cfg.readBufferSize, "read-buffer", 1024*64, "读取输入文件的缓冲区大小（字节）")
+	flag.DurationVar(&cfg.globalTimeout, "timeout", 10*time.Minute, "全局超时设置")
+	flag.StringVar(&cfg.linkColumn, "link-column", defaultLinkColumn, "CSV 表头中链接所在的列名")
+	flag.StringVar(&cfg.method, "method", "GET", "请求方法，GET 或 HEAD")
+	flag.StringVar(&cfg.userAgent, "user-agent", "", "请求携带的 User-Agent，留空则使用 Go 默认值")
+	flag.BoolVar(&cfg.insecure, "insecure", false, "跳过 TLS 证书校验")
+	flag.StringVar(&cfg.acceptStatus, "accept-status", "200-299", "视为有效的状态码，支持区间（200-399）或正则")
+	flag.IntVar(&cfg.maxRetries, "max-retries", 3, "单个 URL 最多尝试次数（含首次请求）")
+	flag.StringVar(&cfg.delimiter, "delimiter", ",", "CSV 分隔符，支持单个字符或 tab/semicolon")
+	flag.BoolVar(&cfg.resume, "resume", false, "从上次的 checkpoint 文件继续，跳过已处理的链接")
+	flag.Parse()
+
+	cfg.method = strings.ToUpper(cfg.method)
+	if cfg.method != "GET" && cfg.method != "HEAD" {
+		return nil, fmt.Errorf("不支持的 -method: %s（仅支持 GET 或 HEAD）", cfg.method)
 	}
-}
 
-// 检查 URL 是否有效
-func checkURL(ctx context.Context, client *http.Client, url string) bool {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if cfg.maxConcurrency <= 0 {
+		return nil, fmt.Errorf("无效的 -concurrency: %d（必须大于 0）", cfg.maxConcurrency)
+	}
 
+	accept, err := parseAcceptStatus(cfg.acceptStatus)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	resp, err := client.Do(req)
+	cfg.accept = accept
 
+	delimiter, err := parseDelimiter(cfg.delimiter)
 	if err != nil {
-		return false
+		return nil, err
 	}
+	cfg.delimiterRune = delimiter
 
-	defer func(Body io.ReadCloser) {
-		if err := Body.Close(); err != nil {
-			return
-		}
-	}(resp.Body)
-	lineCount.Increment()
+	return cfg, nil
+}
+
+// parseDelimiter 把 -delimiter 的值解析成 csv.Reader 使用的分隔符，支持便捷别名 tab/semicolon
+func parseDelimiter(spec string) (rune, error) {
+	switch spec {
+	case "tab":
+		return '\t', nil
+	case "semicolon":
+		return ';', nil
+	}
 
-	return resp.StatusCode >= 200 && resp.StatusCode < 300
+	runes := []rune(spec)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("无效的 -delimiter: %q（需为单个字符，或 tab/semicolon）", spec)
+	}
+	return runes[0], nil
 }
 
-// 批量写入文件
-func writeCSVFile(writer *csv.Writer, rows [][]string) {
-	for _, row := range rows {
-		if err := writer.Write(row); err != nil {
-			return
+// parseAcceptStatus 把 -accept-status 的值编译成状态码判定函数，支持 "200-399" 风格的区间或正则表达式
+func parseAcceptStatus(spec string) (func(code int) bool, error) {
+	if lo, hi, ok := parseStatusRange(spec); ok {
+		return func(code int) bool { return code >= lo && code <= hi }, nil
+	}
+
+	re, err := regexp.Compile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 -accept-status: %w", err)
+	}
+	return func(code int) bool { return re.MatchString(strconv.Itoa(code)) }, nil
+}
+
+func parseStatusRange(spec string) (lo, hi int, ok bool) {
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	lo, errLo := strconv.Atoi(strings.TrimSpace(parts[0]))
+	hi, errHi := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errLo != nil || errHi != nil {
+		return 0, 0, false
+	}
+
+	return lo, hi, true
+}
+
+// Stats 并发安全地累计处理进度，按结果分类计数
+type Stats struct {
+	total     atomic.Int64
+	ok        atomic.Int64
+	fail      atomic.Int64
+	timeout   atomic.Int64
+	retried   atomic.Int64
+	bytesRead atomic.Int64
+}
+
+// StatsSnapshot 是 Stats 在某一时刻的值类型快照，供进度条格式化展示
+type StatsSnapshot struct {
+	Total     int64
+	OK        int64
+	Fail      int64
+	Timeout   int64
+	Retried   int64
+	BytesRead int64
+}
+
+// Record 按一次 URL 检测结果更新计数
+func (s *Stats) Record(result urlcheck.Result) {
+	s.total.Add(1)
+	if result.OK() {
+		s.ok.Add(1)
+	} else {
+		s.fail.Add(1)
+	}
+	if result.ErrorClass == "timeout" {
+		s.timeout.Add(1)
+	}
+	if result.Attempts > 1 {
+		s.retried.Add(1)
+	}
+	s.bytesRead.Add(result.BytesRead)
+}
+
+func (s *Stats) Snapshot() StatsSnapshot {
+	return StatsSnapshot{
+		Total:     s.total.Load(),
+		OK:        s.ok.Load(),
+		Fail:      s.fail.Load(),
+		Timeout:   s.timeout.Load(),
+		Retried:   s.retried.Load(),
+		BytesRead: s.bytesRead.Load(),
+	}
+}
+
+// 创建 HTTP 客户端，启用持久连接
+func createHttpClient(cfg *config) *http.Client {
+	return &http.Client{
+		Timeout: cfg.httpTimeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: false, // 启用持久连接
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: cfg.insecure},
+		},
+	}
+}
+
+// runOutputWriter 从 ch 消费分类结果并写入 writer，直到 ch 被关闭；定期 Flush 让输出增量可见
+func runOutputWriter(ch <-chan []string, writer *csv.Writer, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case row, ok := <-ch:
+			if !ok {
+				writer.Flush()
+				return
+			}
+			if err := writer.Write(row); err != nil {
+				fmt.Println("写入失败:", err)
+			}
+		case <-ticker.C:
+			writer.Flush()
 		}
 	}
-	writer.Flush()
 }
 
+// countLines 统计输入文件的行数，用于初始化进度条；标准输入无法预先统计，返回 0
 func countLines(filePath string) (int, error) {
+	if filePath == "-" {
+		return 0, nil
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return 0, err
@@ -124,143 +294,230 @@ func countLines(filePath string) (int, error) {
 	return lineCount, nil
 }
 
-// 处理 CSV 文件，过滤有效和无效链接
-func processCSV(ctx context.Context) {
-	lineCount.processed.Store(0)
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
 
-	// 打开输入 CSV 文件
-	file, err := os.Open(inputFile)
+// skipBOM 探测并跳过 UTF-8 BOM，避免它混入表头的第一个字段名
+func skipBOM(reader *bufio.Reader) {
+	prefix, err := reader.Peek(len(utf8BOM))
 	if err != nil {
-		fmt.Println("无法打开输入文件:", err)
 		return
 	}
-	defer file.Close()
+	if bytes.Equal(prefix, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+}
 
-	// 创建输出 CSV 文件
-	goodFile, err := os.Create(goodFile)
-	if err != nil {
-		fmt.Println("无法创建 good.csv 文件:", err)
-		return
+// openInput 按路径打开输入源，"-" 表示从标准输入读取
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
 	}
-	defer goodFile.Close()
+	return os.Open(path)
+}
+
+// openOutput 按路径创建输出目的地，"-" 表示写到标准输出；resume 为 true 时续写而非清空
+func openOutput(path string, resume bool) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	if resume {
+		return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	}
+	return os.Create(path)
+}
 
-	badFile, err := os.Create(badFile)
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// checkpointPath 返回记录已处理链接的 checkpoint 文件路径；标准输入无法续传，返回空字符串
+func checkpointPath(inputPath string) string {
+	if inputPath == "-" {
+		return ""
+	}
+	return inputPath + ".progress"
+}
+
+// loadCheckpoint 读取 checkpoint 文件中已处理过的链接集合；文件不存在时返回空集合
+func loadCheckpoint(path string) (map[string]struct{}, error) {
+	done := make(map[string]struct{})
+	if path == "" {
+		return done, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
 	if err != nil {
-		fmt.Println("无法创建 bad.csv 文件:", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		done[scanner.Text()] = struct{}{}
+	}
+	return done, scanner.Err()
+}
+
+// runCheckpointWriter 把已处理的链接追加写入 checkpoint 文件，支持 -resume 跳过
+func runCheckpointWriter(ch <-chan string, file *os.File, wg *sync.WaitGroup) {
+	defer wg.Done()
+	if file == nil {
+		for range ch {
+		}
 		return
 	}
-	defer badFile.Close()
 
-	goodWriter := csv.NewWriter(goodFile)
-	badWriter := csv.NewWriter(badFile)
+	writer := bufio.NewWriter(file)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 
-	// 使用 bufio.Reader 读取文件
-	reader := bufio.NewReaderSize(file, readBufferSize) // 使用 64KB 缓冲区
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, maxConcurrency) // 控制最大并发数
+	for {
+		select {
+		case url, ok := <-ch:
+			if !ok {
+				writer.Flush()
+				return
+			}
+			fmt.Fprintln(writer, url)
+		case <-ticker.C:
+			writer.Flush()
+		}
+	}
+}
 
-	client := createHttpClient() // 创建 HTTP 客户端
+// 处理 CSV 文件，过滤有效和无效链接；URL 检测本身委托给 pkg/urlcheck
+func processCSV(ctx context.Context, cfg *config) {
+	stats := &Stats{}
 
-	// 读取表头，获取发布链接的列索引
-	csvReader := csv.NewReader(reader)
-	header, err := csvReader.Read()
+	// 打开输入源
+	file, err := openInput(cfg.inputPath)
 	if err != nil {
-		fmt.Println("无法读取文件表头:", err)
+		fmt.Println("无法打开输入文件:", err)
 		return
 	}
+	defer file.Close()
 
-	// 确保表头中有"发布链接"这一列
-	linkColumnIndex := -1
-	for i, col := range header {
-		if strings.TrimSpace(col) == "发布链接" {
-			linkColumnIndex = i
-			break
+	// 加载 checkpoint，-resume 时跳过已处理过的链接
+	ckptPath := checkpointPath(cfg.inputPath)
+	done := make(map[string]struct{})
+	if cfg.resume {
+		var err error
+		done, err = loadCheckpoint(ckptPath)
+		if err != nil {
+			fmt.Println("无法读取 checkpoint 文件:", err)
+			return
 		}
 	}
 
-	if linkColumnIndex == -1 {
-		fmt.Println("未找到发布链接列")
+	// 创建输出目的地，-resume 时续写而不是清空
+	goodFile, err := openOutput(cfg.goodPath, cfg.resume)
+	if err != nil {
+		fmt.Println("无法创建 good 输出:", err)
 		return
 	}
+	defer goodFile.Close()
 
-	// 打印进度
-	n, err := countLines(inputFile)
+	badFile, err := openOutput(cfg.badPath, cfg.resume)
 	if err != nil {
+		fmt.Println("无法创建 bad 输出:", err)
 		return
 	}
-	go func(count int) {
-		defer fmt.Println("\n进度监控结束")       // 可选，退出时输出结束提示
-		ticker := time.NewTicker(2 * time.Second) // 使用 ticker 更好地控制进度打印频率
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-			case <-ticker.C:
-				progress := float64(lineCount.GetProcessed()) / float64(count) * 100
-				fmt.Printf("\r进度：%.2f%% (%v/%v)\n", progress, lineCount.GetProcessed(), count)
-			}
-		}
-	}(n)
+	defer badFile.Close()
 
-	// 逐行读取 CSV 文件
-	var goodRows, badRows [][]string
+	goodWriter := csv.NewWriter(goodFile)
+	badWriter := csv.NewWriter(badFile)
 
-	for {
-		record, err := reader.ReadString('\n') // 逐行读取
+	var ckptFile *os.File
+	if ckptPath != "" {
+		ckptFile, err = os.OpenFile(ckptPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
-			break // 到达文件末尾
-		}
-
-		// 去掉换行符
-		record = strings.TrimSpace(record)
-
-		// 将一行记录解析为 CSV 格式
-		recordFields := strings.Split(record, ",")
-		if len(recordFields) < len(header) {
-			continue // 跳过不完整的行
+			fmt.Println("无法打开 checkpoint 文件:", err)
+			return
 		}
+		defer ckptFile.Close()
+	}
 
-		// 获取发布链接列的内容
-		url := recordFields[linkColumnIndex]
-
-		// 等待可用的信号量
-		sem <- struct{}{}
-		wg.Add(1)
-
-		// 并发执行链接检查
-		go func(record []string) {
-			defer wg.Done()
-			defer func() { <-sem }() // 释放信号量
-
-			// 检查 URL 是否有效
-			isValid := checkURL(ctx, client, url)
+	// 使用 bufio.Reader 读取文件
+	reader := bufio.NewReaderSize(file, cfg.readBufferSize)
+	skipBOM(reader)
 
-			// 将有效或无效的行存入相应的数组
-			if isValid {
-				goodRows = append(goodRows, record)
-			} else {
-				badRows = append(badRows, record)
-			}
-		}(recordFields)
+	// 统计总行数并初始化进度条
+	n, err := countLines(cfg.inputPath)
+	if err != nil {
+		return
+	}
+	bar := newProgressBar(n)
+
+	// 每个输出文件各有一个写入 goroutine，通过 channel 接收分类结果，避免并发写共享切片
+	goodCh := make(chan []string, cfg.maxConcurrency)
+	badCh := make(chan []string, cfg.maxConcurrency)
+	ckptCh := make(chan string, cfg.maxConcurrency)
+	var writerWg sync.WaitGroup
+	writerWg.Add(3)
+	go runOutputWriter(goodCh, goodWriter, &writerWg)
+	go runOutputWriter(badCh, badWriter, &writerWg)
+	go runCheckpointWriter(ckptCh, ckptFile, &writerWg)
+
+	checker := urlcheck.NewHTTPChecker(createHttpClient(cfg), urlcheck.Options{
+		Method:     cfg.method,
+		UserAgent:  cfg.userAgent,
+		MaxRetries: cfg.maxRetries,
+		Accept:     cfg.accept,
+	})
+
+	pipeline := &urlcheck.Pipeline{
+		Checker:     checker,
+		Concurrency: cfg.maxConcurrency,
+		LinkColumn:  cfg.linkColumn,
+		Delimiter:   cfg.delimiterRune,
+		Skip: func(url string) bool {
+			_, ok := done[url]
+			return ok
+		},
+		OnResult: func(url string, result urlcheck.Result) {
+			stats.Record(result)
+			bar.render(stats.Snapshot())
+			ckptCh <- url
+		},
 	}
 
-	// 等待所有 goroutines 完成
-	wg.Wait()
+	if err := pipeline.Run(ctx, reader, goodCh, badCh); err != nil {
+		fmt.Println(err)
+	}
+	bar.finish(stats.Snapshot())
 
-	// 批量写入 CSV 文件
-	writeCSVFile(goodWriter, goodRows)
-	writeCSVFile(badWriter, badRows)
+	close(goodCh)
+	close(badCh)
+	close(ckptCh)
+	writerWg.Wait()
 
-	fmt.Println("处理完毕，生成文件：good.csv 和 bad.csv")
+	if ctx.Err() != nil {
+		fmt.Println("已停止并保存进度:", ctx.Err())
+		return
+	}
+	fmt.Println("处理完毕")
 }
 
 func main() {
-	// 创建一个带有全局超时的 context
-	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
+	cfg, err := parseFlags()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Ctrl-C / SIGTERM 触发优雅退出，叠加一个全局超时兜底
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.globalTimeout)
 	defer cancel()
 
 	start := time.Now()
-	processCSV(ctx)
+	processCSV(ctx, cfg)
 	fmt.Printf("执行时间: %v\n", time.Since(start))
 }
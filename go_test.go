@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDelimiter(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    rune
+		wantErr bool
+	}{
+		{"tab", '\t', false},
+		{"semicolon", ';', false},
+		{",", ',', false},
+		{"|", '|', false},
+		{"", 0, true},
+		{"too-long", 0, true},
+	}
+
+	for _, tc := range cases {
+		got, err := parseDelimiter(tc.spec)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseDelimiter(%q) expected an error, got nil", tc.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDelimiter(%q) unexpected error: %v", tc.spec, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseDelimiter(%q) = %q, want %q", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestParseStatusRange(t *testing.T) {
+	lo, hi, ok := parseStatusRange("200-399")
+	if !ok || lo != 200 || hi != 399 {
+		t.Fatalf("parseStatusRange(200-399) = (%d, %d, %v), want (200, 399, true)", lo, hi, ok)
+	}
+
+	if _, _, ok := parseStatusRange("not-a-range"); ok {
+		t.Fatal("parseStatusRange(not-a-range) should not parse as a range")
+	}
+
+	if _, _, ok := parseStatusRange("^2\\d\\d$"); ok {
+		t.Fatal("parseStatusRange should leave regex specs to the regex fallback")
+	}
+}
+
+func TestParseAcceptStatus(t *testing.T) {
+	accept, err := parseAcceptStatus("200-299")
+	if err != nil {
+		t.Fatalf("parseAcceptStatus(200-299) unexpected error: %v", err)
+	}
+	if !accept(250) || accept(199) || accept(300) {
+		t.Fatal("parseAcceptStatus(200-299) range boundaries are wrong")
+	}
+
+	acceptRe, err := parseAcceptStatus("^2\\d\\d$")
+	if err != nil {
+		t.Fatalf("parseAcceptStatus(regex) unexpected error: %v", err)
+	}
+	if !acceptRe(200) || acceptRe(404) {
+		t.Fatal("parseAcceptStatus(regex) did not match expected status codes")
+	}
+
+	if _, err := parseAcceptStatus("["); err == nil {
+		t.Fatal("parseAcceptStatus([) should fail to compile as a regex")
+	}
+}
+
+func TestCheckpointPath(t *testing.T) {
+	if got := checkpointPath("-"); got != "" {
+		t.Fatalf("checkpointPath(-) = %q, want empty", got)
+	}
+	if got := checkpointPath("data.csv"); got != "data.csv.progress" {
+		t.Fatalf("checkpointPath(data.csv) = %q, want data.csv.progress", got)
+	}
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.csv.progress")
+	if err := os.WriteFile(path, []byte("http://a.example\nhttp://b.example\n"), 0644); err != nil {
+		t.Fatalf("failed to seed checkpoint file: %v", err)
+	}
+
+	done, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint unexpected error: %v", err)
+	}
+	if _, ok := done["http://a.example"]; !ok {
+		t.Error("expected http://a.example to be marked done")
+	}
+	if _, ok := done["http://c.example"]; ok {
+		t.Error("did not expect http://c.example to be marked done")
+	}
+}
+
+func TestLoadCheckpointMissingFile(t *testing.T) {
+	done, err := loadCheckpoint(filepath.Join(t.TempDir(), "missing.progress"))
+	if err != nil {
+		t.Fatalf("loadCheckpoint on a missing file should not error, got: %v", err)
+	}
+	if len(done) != 0 {
+		t.Fatalf("expected an empty set for a missing checkpoint file, got %d entries", len(done))
+	}
+}
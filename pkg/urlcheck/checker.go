@@ -0,0 +1,199 @@
+// Package urlcheck 提供可复用的 URL 存活检测能力：Checker 接口、默认的
+// HTTPChecker 实现，以及把一批记录跑过 Checker 的 Pipeline。
+package urlcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	baseBackoff = 200 * time.Millisecond
+	maxBackoff  = 5 * time.Second
+)
+
+// Result 是一次 URL 检测的诊断结果
+type Result struct {
+	StatusCode int
+	FinalURL   string
+	LatencyMs  int64
+	Attempts   int
+	ErrorClass string
+	BytesRead  int64 // 实际读取的响应体字节数
+}
+
+// OK 报告这次检测是否被判定为有效
+func (r Result) OK() bool {
+	return r.ErrorClass == "ok"
+}
+
+// Row 把诊断结果渲染成可以追加到原始 CSV 记录后的字符串列
+func (r Result) Row() []string {
+	return []string{
+		strconv.Itoa(r.StatusCode),
+		r.FinalURL,
+		strconv.FormatInt(r.LatencyMs, 10),
+		strconv.Itoa(r.Attempts),
+		r.ErrorClass,
+	}
+}
+
+// Checker 检测单个 URL 并返回诊断结果，调用方可以提供自己的实现（mock、ws://、ftp:// 等）
+type Checker interface {
+	Check(ctx context.Context, url string) Result
+}
+
+// Options 配置 HTTPChecker 的请求行为
+type Options struct {
+	Method     string              // 请求方法，GET 或 HEAD
+	UserAgent  string              // 请求携带的 User-Agent，留空则使用 Go 默认值
+	MaxRetries int                 // 单个 URL 最多尝试次数（含首次请求）
+	Accept     func(code int) bool // 判定某个状态码是否视为有效
+}
+
+// HTTPChecker 是基于 http.Client 的默认 Checker 实现，失败时按指数退避 + 抖动重试
+type HTTPChecker struct {
+	client *http.Client
+	opts   Options
+}
+
+// NewHTTPChecker 构造一个 HTTPChecker；opts 中未设置的字段会退回到合理的默认值
+func NewHTTPChecker(client *http.Client, opts Options) *HTTPChecker {
+	if opts.Method == "" {
+		opts.Method = "GET"
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 1
+	}
+	if opts.Accept == nil {
+		opts.Accept = func(code int) bool { return code >= 200 && code < 300 }
+	}
+	return &HTTPChecker{client: client, opts: opts}
+}
+
+// Check 实现 Checker，失败时按指数退避 + 抖动重试，返回带诊断信息的结果
+func (c *HTTPChecker) Check(ctx context.Context, url string) Result {
+	start := time.Now()
+	var result Result
+
+	for attempt := 1; attempt <= c.opts.MaxRetries; attempt++ {
+		result = c.attemptOnce(ctx, url, attempt, start)
+
+		if result.OK() || !retryable(result.ErrorClass) || attempt == c.opts.MaxRetries {
+			break
+		}
+
+		timer := time.NewTimer(backoffWithJitter(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+
+	return result
+}
+
+func (c *HTTPChecker) attemptOnce(ctx context.Context, url string, attempt int, start time.Time) Result {
+	req, err := http.NewRequestWithContext(ctx, c.opts.Method, url, nil)
+	if err != nil {
+		return Result{Attempts: attempt, LatencyMs: time.Since(start).Milliseconds(), ErrorClass: "request"}
+	}
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		class := classifyError(err)
+		if ctx.Err() != nil {
+			// ctx 本身已经结束（SIGINT/SIGTERM 或 -timeout 全局超时），
+			// 而不是这次请求自己的 -http-timeout；不要把它当作可重试的
+			// 普通超时，否则会对一个已经死掉的 ctx 继续退避重试。
+			class = "shutdown"
+		}
+		return Result{Attempts: attempt, LatencyMs: time.Since(start).Milliseconds(), ErrorClass: class}
+	}
+	defer resp.Body.Close()
+
+	bytesRead, _ := io.Copy(io.Discard, resp.Body)
+
+	return Result{
+		StatusCode: resp.StatusCode,
+		FinalURL:   resp.Request.URL.String(),
+		LatencyMs:  time.Since(start).Milliseconds(),
+		Attempts:   attempt,
+		ErrorClass: classifyStatus(resp.StatusCode, c.opts.Accept),
+		BytesRead:  bytesRead,
+	}
+}
+
+// classifyStatus 把 HTTP 状态码归类为诊断用的 error_class，accept 决定哪些状态码视为有效
+func classifyStatus(code int, accept func(int) bool) string {
+	switch {
+	case accept(code):
+		return "ok"
+	case code >= 400 && code < 500:
+		return "http_4xx"
+	case code >= 500:
+		return "http_5xx"
+	default:
+		return "http_other"
+	}
+}
+
+// classifyError 把请求失败的 error 归类为诊断用的 error_class
+func classifyError(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "shutdown"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout || dnsErr.IsTemporary {
+			return "dns"
+		}
+		return "dns_not_found"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+
+	if strings.Contains(err.Error(), "tls:") || strings.Contains(err.Error(), "x509:") {
+		return "tls"
+	}
+
+	return "conn"
+}
+
+// retryable 判断某次失败是否值得再试一次
+func retryable(class string) bool {
+	switch class {
+	case "dns", "timeout", "conn", "http_5xx":
+		return true
+	default:
+		return false
+	}
+}
+
+// backoffWithJitter 计算第 attempt 次重试前应等待的时长（指数退避 + 抖动）
+func backoffWithJitter(attempt int) time.Duration {
+	d := baseBackoff * time.Duration(1<<uint(attempt-1))
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(d) + 1))
+	return d/2 + jitter/2
+}
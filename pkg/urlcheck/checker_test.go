@@ -0,0 +1,110 @@
+package urlcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"canceled", context.Canceled, "shutdown"},
+		{"deadline exceeded", context.DeadlineExceeded, "timeout"},
+		{"dns not found", &net.DNSError{Err: "no such host", IsNotFound: true}, "dns_not_found"},
+		{"dns timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, "dns"},
+		{"dns temporary", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, "dns"},
+		{"net timeout", timeoutError{}, "timeout"},
+		{"tls error", errors.New("tls: handshake failure"), "tls"},
+		{"x509 error", errors.New("x509: certificate signed by unknown authority"), "tls"},
+		{"generic connection error", errors.New("connection refused"), "conn"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyError(tc.err); got != tc.want {
+				t.Errorf("classifyError(%v) = %q, want %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	accept := func(code int) bool { return code >= 200 && code < 300 }
+
+	cases := []struct {
+		code int
+		want string
+	}{
+		{200, "ok"},
+		{404, "http_4xx"},
+		{503, "http_5xx"},
+		{301, "http_other"},
+	}
+
+	for _, tc := range cases {
+		if got := classifyStatus(tc.code, accept); got != tc.want {
+			t.Errorf("classifyStatus(%d) = %q, want %q", tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	retryableClasses := []string{"dns", "timeout", "conn", "http_5xx"}
+	for _, class := range retryableClasses {
+		if !retryable(class) {
+			t.Errorf("retryable(%q) = false, want true", class)
+		}
+	}
+
+	notRetryableClasses := []string{"ok", "dns_not_found", "shutdown", "http_4xx", "tls", "request"}
+	for _, class := range notRetryableClasses {
+		if retryable(class) {
+			t.Errorf("retryable(%q) = true, want false", class)
+		}
+	}
+}
+
+func TestBackoffWithJitter(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := backoffWithJitter(attempt)
+		if d < 0 || d > maxBackoff {
+			t.Errorf("backoffWithJitter(%d) = %v, want within [0, %v]", attempt, d, maxBackoff)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestHTTPCheckerCheckDoesNotRetryCanceledContext(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, context.Canceled
+		}),
+	}
+	checker := NewHTTPChecker(client, Options{MaxRetries: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := checker.Check(ctx, "http://example.invalid")
+	if result.ErrorClass != "shutdown" {
+		t.Fatalf("expected shutdown, got %q", result.ErrorClass)
+	}
+	if result.Attempts != 1 {
+		t.Fatalf("expected to not burn retries against a dead context, got attempts=%d", result.Attempts)
+	}
+}
@@ -0,0 +1,117 @@
+package urlcheck
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Pipeline 把一个记录流跑过 Checker，按检测结果把记录分流到 good/bad 两个 channel
+type Pipeline struct {
+	Checker     Checker
+	Concurrency int
+	LinkColumn  string // CSV 表头中链接所在的列名
+	Delimiter   rune   // CSV 分隔符，零值表示使用 encoding/csv 的默认值 ','
+
+	// Skip 可选，返回 true 时该 URL 不会被检测（用于断点续传）
+	Skip func(url string) bool
+	// OnResult 可选，每完成一次检测就会被调用一次（用于进度展示、写 checkpoint 等）
+	OnResult func(url string, result Result)
+}
+
+// Run 从 r 中读取带表头的 CSV 记录，并发检测每一行的链接列，把原始记录加上诊断列后
+// 写入 good 或 bad。Run 会阻塞到输入耗尽或 ctx 被取消，并在返回前等待所有在途请求完成；
+// 调用方负责在 Run 返回后关闭 good/bad。
+func (p *Pipeline) Run(ctx context.Context, r io.Reader, good, bad chan<- []string) error {
+	reader := csv.NewReader(r)
+	if p.Delimiter != 0 {
+		reader.Comma = p.Delimiter
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("读取表头失败: %w", err)
+	}
+
+	linkIndex := -1
+	for i, col := range header {
+		if strings.TrimSpace(col) == p.LinkColumn {
+			linkIndex = i
+			break
+		}
+	}
+	if linkIndex == -1 {
+		return fmt.Errorf("未找到链接列 %q", p.LinkColumn)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, p.Concurrency)
+
+readLoop:
+	for {
+		select {
+		case <-ctx.Done():
+			break readLoop
+		default:
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 行级别的解析错误（引号不匹配、列数不一致等）记为 bad，而不是中止整个扫描
+			row := make([]string, len(header))
+			if len(row) > 0 {
+				row[0] = fmt.Sprintf("parse_error: %v", err)
+			}
+			bad <- append(row, Result{ErrorClass: "parse"}.Row()...)
+			continue
+		}
+
+		if len(record) < len(header) {
+			continue // 跳过不完整的行
+		}
+
+		url := record[linkIndex]
+		if p.Skip != nil && p.Skip(url) {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break readLoop
+		}
+		wg.Add(1)
+
+		go func(record []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := p.Checker.Check(ctx, url)
+			if ctx.Err() != nil {
+				// 请求是被关停信号中断的，不是真正检测完成；不记录到
+				// good/bad 或 checkpoint，以便 -resume 时重新检测它。
+				return
+			}
+
+			if p.OnResult != nil {
+				p.OnResult(url, result)
+			}
+
+			enriched := append(append([]string{}, record...), result.Row()...)
+			if result.OK() {
+				good <- enriched
+			} else {
+				bad <- enriched
+			}
+		}(record)
+	}
+
+	wg.Wait()
+	return nil
+}
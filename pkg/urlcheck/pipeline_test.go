@@ -0,0 +1,144 @@
+package urlcheck
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeChecker 按 url 返回预先配置好的结果，用于在不发起真实请求的情况下测试 Pipeline
+type fakeChecker struct {
+	results map[string]Result
+	delay   time.Duration
+}
+
+func (f *fakeChecker) Check(ctx context.Context, url string) Result {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+		}
+	}
+	return f.results[url]
+}
+
+func drain(ch <-chan []string) []([]string) {
+	var rows [][]string
+	for row := range ch {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestPipelineRunRoutesGoodAndBad(t *testing.T) {
+	input := "发布链接\nhttp://good.example\nhttp://bad.example\n"
+	checker := &fakeChecker{results: map[string]Result{
+		"http://good.example": {ErrorClass: "ok", StatusCode: 200},
+		"http://bad.example":  {ErrorClass: "http_4xx", StatusCode: 404},
+	}}
+
+	good := make(chan []string, 10)
+	bad := make(chan []string, 10)
+	pipeline := &Pipeline{Checker: checker, Concurrency: 2, LinkColumn: "发布链接"}
+
+	if err := pipeline.Run(context.Background(), strings.NewReader(input), good, bad); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	close(good)
+	close(bad)
+
+	goodRows := drain(good)
+	badRows := drain(bad)
+	if len(goodRows) != 1 || len(badRows) != 1 {
+		t.Fatalf("expected 1 good and 1 bad row, got %d good, %d bad", len(goodRows), len(badRows))
+	}
+}
+
+func TestPipelineRunHonorsSkip(t *testing.T) {
+	input := "发布链接\nhttp://skip.example\nhttp://check.example\n"
+	checker := &fakeChecker{results: map[string]Result{
+		"http://check.example": {ErrorClass: "ok"},
+	}}
+
+	good := make(chan []string, 10)
+	bad := make(chan []string, 10)
+	var seen []string
+	var mu sync.Mutex
+	pipeline := &Pipeline{
+		Checker:     checker,
+		Concurrency: 2,
+		LinkColumn:  "发布链接",
+		Skip:        func(url string) bool { return url == "http://skip.example" },
+		OnResult: func(url string, result Result) {
+			mu.Lock()
+			seen = append(seen, url)
+			mu.Unlock()
+		},
+	}
+
+	if err := pipeline.Run(context.Background(), strings.NewReader(input), good, bad); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	close(good)
+	close(bad)
+
+	if len(seen) != 1 || seen[0] != "http://check.example" {
+		t.Fatalf("expected only http://check.example to be checked, got %v", seen)
+	}
+}
+
+func TestPipelineRunRecordsParseErrorsAsBad(t *testing.T) {
+	input := "发布链接,备注\nhttp://only-one-field.example\n"
+	checker := &fakeChecker{results: map[string]Result{}}
+
+	good := make(chan []string, 10)
+	bad := make(chan []string, 10)
+	pipeline := &Pipeline{Checker: checker, Concurrency: 2, LinkColumn: "发布链接"}
+
+	if err := pipeline.Run(context.Background(), strings.NewReader(input), good, bad); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	close(good)
+	close(bad)
+
+	badRows := drain(bad)
+	if len(badRows) != 1 {
+		t.Fatalf("expected the short row to be recorded as bad, got %d rows", len(badRows))
+	}
+}
+
+func TestPipelineRunDropsAbortedRequestsOnCancel(t *testing.T) {
+	input := "发布链接\nhttp://slow.example\n"
+	checker := &fakeChecker{
+		delay:   time.Second,
+		results: map[string]Result{"http://slow.example": {ErrorClass: "ok"}},
+	}
+
+	good := make(chan []string, 10)
+	bad := make(chan []string, 10)
+	onResultCalled := false
+	pipeline := &Pipeline{
+		Checker:     checker,
+		Concurrency: 2,
+		LinkColumn:  "发布链接",
+		OnResult:    func(string, Result) { onResultCalled = true },
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := pipeline.Run(ctx, strings.NewReader(input), good, bad); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	close(good)
+	close(bad)
+
+	if onResultCalled {
+		t.Fatal("OnResult should not be called for a request aborted by cancellation")
+	}
+	if len(drain(good))+len(drain(bad)) != 0 {
+		t.Fatal("an aborted request should not be routed to good or bad")
+	}
+}